@@ -0,0 +1,138 @@
+package mixpanel
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxBatchSize is the maximum number of events or updates Mixpanel accepts
+// in a single /track or /engage batch request.
+const maxBatchSize = 50
+
+// BatchEvent pairs an event with the distinct ID it belongs to, for use
+// with TrackBatch.
+type BatchEvent struct {
+	DistinctID string
+	EventName  string
+	Event      *Event
+}
+
+// BatchUpdate pairs a profile update with the distinct ID it belongs to,
+// for use with UpdateBatch.
+type BatchUpdate struct {
+	DistinctID string
+	Update     *Update
+}
+
+func (m *mixpanel) TrackBatch(events []BatchEvent) error {
+	params := make([]interface{}, len(events))
+	for i, e := range events {
+		params[i] = m.trackParams(e.EventName, e.DistinctID, e.Event)
+	}
+
+	return m.sendBatches("/track", params)
+}
+
+func (m *mixpanel) UpdateBatch(updates []BatchUpdate) error {
+	params := make([]interface{}, len(updates))
+	for i, u := range updates {
+		params[i] = m.updateParams(u.DistinctID, u.Update)
+	}
+
+	return m.sendBatches("/engage", params)
+}
+
+// sendBatches splits params into chunks of at most maxBatchSize and posts
+// each chunk to endpoint. A failing chunk does not stop later chunks from
+// being sent, so a single bad batch can't silently drop every event
+// behind it; every failure is collected and returned together as a
+// *BatchSendError (or, if only one chunk failed, as that chunk's own
+// error).
+func (m *mixpanel) sendBatches(endpoint string, params []interface{}) error {
+	var errs []error
+
+	for start := 0; start < len(params); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(params) {
+			end = len(params)
+		}
+
+		if err := m.sendBatch(endpoint, params[start:end]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return &BatchSendError{Errors: errs}
+	}
+}
+
+// BatchSendError aggregates the failures from a multi-batch
+// TrackBatch/UpdateBatch call, so a caller (or BufferOptions.OnError)
+// can see every batch that failed, not just the first.
+type BatchSendError struct {
+	Errors []error
+}
+
+func (err *BatchSendError) Error() string {
+	msgs := make([]string, len(err.Errors))
+	for i, e := range err.Errors {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("mixpanel: %d batch(es) failed: %s", len(err.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap lets errors.Is/errors.As inspect any of the underlying batch
+// errors.
+func (err *BatchSendError) Unwrap() []error {
+	return err.Errors
+}
+
+// sendBatch POSTs a single batch of at most maxBatchSize events or
+// updates. Batches are sent as a POST with a form-encoded body, rather
+// than the query string used by single sends, since a batch payload can
+// easily exceed URL length limits.
+func (m *mixpanel) sendBatch(endpoint string, batch []interface{}) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	reqURL := m.ApiURL + endpoint
+	form := url.Values{"data": {base64.StdEncoding.EncodeToString(data)}}.Encode()
+
+	ctx, cancel := m.callContext(context.Background())
+	defer cancel()
+
+	return m.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, strings.NewReader(form))
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := m.Client.Do(req)
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+
+		return checkResponse(reqURL, body, resp.StatusCode, resp.Header)
+	})
+}
@@ -0,0 +1,127 @@
+package mixpanel
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// decodeBatchBody decodes a batch POST's form-encoded, base64'd "data"
+// field into the array of event/update payloads it carries.
+func decodeBatchBody(t *testing.T, r *http.Request) []map[string]interface{} {
+	t.Helper()
+
+	if err := r.ParseForm(); err != nil {
+		t.Fatalf("ParseForm: %v", err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(r.FormValue("data"))
+	if err != nil {
+		t.Fatalf("decode data param: %v", err)
+	}
+
+	var batch []map[string]interface{}
+	if err := json.Unmarshal(decoded, &batch); err != nil {
+		t.Fatalf("unmarshal batch: %v", err)
+	}
+
+	return batch
+}
+
+func TestTrackBatchSplitsIntoChunksOfMaxBatchSize(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var batchSizes []int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := decodeBatchBody(t, r)
+
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		batchSizes = append(batchSizes, len(batch))
+		mu.Unlock()
+
+		w.WriteHeader(200)
+		w.Write([]byte("1\n"))
+	}))
+	defer srv.Close()
+
+	m := New("tok", srv.URL)
+
+	events := make([]BatchEvent, 120)
+	for i := range events {
+		events[i] = BatchEvent{DistinctID: "13793", EventName: "Signed Up", Event: &Event{}}
+	}
+
+	if err := m.TrackBatch(events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 requests for 120 events (50+50+20), got %d", len(paths))
+	}
+
+	for _, p := range paths {
+		if p != "/track" {
+			t.Errorf("request path = %q, want /track", p)
+		}
+	}
+
+	want := []int{50, 50, 20}
+	for i, size := range batchSizes {
+		if size != want[i] {
+			t.Errorf("request %d had %d events, want %d", i, size, want[i])
+		}
+	}
+}
+
+func TestSendBatchesContinuesPastFailedChunk(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decodeBatchBody(t, r)
+
+		// Fail the 1st and 3rd of the 3 chunks, succeed the 2nd, so a
+		// single-chunk failure doesn't mask whether later chunks still
+		// get sent.
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 || n == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte("1\n"))
+	}))
+	defer srv.Close()
+
+	m := New("tok", srv.URL)
+
+	events := make([]BatchEvent, 120)
+	for i := range events {
+		events[i] = BatchEvent{DistinctID: "13793", EventName: "Signed Up", Event: &Event{}}
+	}
+
+	err := m.TrackBatch(events)
+	if err == nil {
+		t.Fatalf("expected an error, since 2 of the 3 chunks failed")
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected all 3 chunks to be sent despite the 1st failing, got %d requests", calls)
+	}
+
+	batchErr, ok := err.(*BatchSendError)
+	if !ok {
+		t.Fatalf("error should be a *BatchSendError when more than one chunk fails, got %T: %v", err, err)
+	}
+	if len(batchErr.Errors) != 2 {
+		t.Fatalf("expected exactly 2 failed chunks recorded, got %d", len(batchErr.Errors))
+	}
+}
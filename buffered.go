@@ -0,0 +1,203 @@
+package mixpanel
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBufferClosed is returned by BufferedClient.Track and
+// BufferedClient.Update once the client has been closed.
+var ErrBufferClosed = errors.New("mixpanel: buffered client is closed")
+
+// BufferOptions configures a BufferedClient.
+type BufferOptions struct {
+	// BatchSize is the number of queued events, or updates, that trigger
+	// an automatic flush. Defaults to the Mixpanel batch limit (50).
+	BatchSize int
+
+	// FlushInterval is the maximum time events or updates are held before
+	// being flushed, regardless of BatchSize. Defaults to 10s.
+	FlushInterval time.Duration
+
+	// QueueSize bounds how many events, or updates, may be queued ahead
+	// of a flush before Track/Update block. Defaults to 1000.
+	QueueSize int
+
+	// OnError, if set, is called from the background flush goroutine
+	// whenever a batch fails to send, so callers can log or dead-letter
+	// the failure instead of silently losing it.
+	OnError func(error)
+}
+
+func (o BufferOptions) withDefaults() BufferOptions {
+	if o.BatchSize <= 0 {
+		o.BatchSize = maxBatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 10 * time.Second
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1000
+	}
+	return o
+}
+
+// BufferedClient wraps a Mixpanel client, accumulating Track and Update
+// calls in memory and flushing them to Mixpanel's batch endpoints from a
+// background goroutine, either once BatchSize is reached or every
+// FlushInterval.
+type BufferedClient struct {
+	client Mixpanel
+	opts   BufferOptions
+
+	events    chan BatchEvent
+	updates   chan BatchUpdate
+	flush     chan chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	wg sync.WaitGroup
+}
+
+// NewBuffered wraps a regular Mixpanel client (see New) in a
+// BufferedClient.
+func NewBuffered(token, apiURL string, opts BufferOptions) *BufferedClient {
+	return newBuffered(New(token, apiURL), opts)
+}
+
+func newBuffered(client Mixpanel, opts BufferOptions) *BufferedClient {
+	opts = opts.withDefaults()
+
+	b := &BufferedClient{
+		client:  client,
+		opts:    opts,
+		events:  make(chan BatchEvent, opts.QueueSize),
+		updates: make(chan BatchUpdate, opts.QueueSize),
+		flush:   make(chan chan struct{}),
+		closed:  make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+// Track enqueues an event to be sent in a future batch.
+func (b *BufferedClient) Track(distinctID, eventName string, e *Event) error {
+	select {
+	case b.events <- BatchEvent{DistinctID: distinctID, EventName: eventName, Event: e}:
+		return nil
+	case <-b.closed:
+		return ErrBufferClosed
+	}
+}
+
+// Update enqueues a profile update to be sent in a future batch.
+func (b *BufferedClient) Update(distinctID string, u *Update) error {
+	select {
+	case b.updates <- BatchUpdate{DistinctID: distinctID, Update: u}:
+		return nil
+	case <-b.closed:
+		return ErrBufferClosed
+	}
+}
+
+// Flush blocks until every event and update queued so far has been sent.
+func (b *BufferedClient) Flush() {
+	done := make(chan struct{})
+
+	select {
+	case b.flush <- done:
+		<-done
+	case <-b.closed:
+	}
+}
+
+// Close flushes any remaining events and updates, then stops the
+// background flush goroutine. It is safe to call Close more than once.
+func (b *BufferedClient) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+	b.wg.Wait()
+	return nil
+}
+
+func (b *BufferedClient) run() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+
+	eventBatch := make([]BatchEvent, 0, b.opts.BatchSize)
+	updateBatch := make([]BatchUpdate, 0, b.opts.BatchSize)
+
+	flushEvents := func() {
+		if len(eventBatch) == 0 {
+			return
+		}
+		if err := b.client.TrackBatch(eventBatch); err != nil && b.opts.OnError != nil {
+			b.opts.OnError(err)
+		}
+		eventBatch = eventBatch[:0]
+	}
+
+	flushUpdates := func() {
+		if len(updateBatch) == 0 {
+			return
+		}
+		if err := b.client.UpdateBatch(updateBatch); err != nil && b.opts.OnError != nil {
+			b.opts.OnError(err)
+		}
+		updateBatch = updateBatch[:0]
+	}
+
+	for {
+		select {
+		case e := <-b.events:
+			eventBatch = append(eventBatch, e)
+			if len(eventBatch) >= b.opts.BatchSize {
+				flushEvents()
+			}
+
+		case u := <-b.updates:
+			updateBatch = append(updateBatch, u)
+			if len(updateBatch) >= b.opts.BatchSize {
+				flushUpdates()
+			}
+
+		case <-ticker.C:
+			flushEvents()
+			flushUpdates()
+
+		case done := <-b.flush:
+			b.drain(&eventBatch, &updateBatch)
+			flushEvents()
+			flushUpdates()
+			close(done)
+
+		case <-b.closed:
+			b.drain(&eventBatch, &updateBatch)
+			flushEvents()
+			flushUpdates()
+			return
+		}
+	}
+}
+
+// drain pulls any events and updates left in the queues on a Flush or
+// Close, without blocking, so they are included in that flush.
+func (b *BufferedClient) drain(eventBatch *[]BatchEvent, updateBatch *[]BatchUpdate) {
+	for {
+		select {
+		case e := <-b.events:
+			*eventBatch = append(*eventBatch, e)
+		case u := <-b.updates:
+			*updateBatch = append(*updateBatch, u)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package mixpanel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestBufferedFlushSendsQueuedEvents is a regression test for 023e33e:
+// Flush must drain events/updates still sitting in the queue, not just
+// whatever had already been pulled into the in-flight batch.
+func TestBufferedFlushSendsQueuedEvents(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := decodeBatchBody(t, r)
+
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+
+		w.WriteHeader(200)
+		w.Write([]byte("1\n"))
+	}))
+	defer srv.Close()
+
+	// A large BatchSize and FlushInterval mean nothing would be sent
+	// automatically before Flush is called.
+	b := NewBuffered("tok", srv.URL, BufferOptions{BatchSize: 100, FlushInterval: time.Hour})
+	defer b.Close()
+
+	const n = 5
+	for i := 0; i < n; i++ {
+		if err := b.Track("13793", "Signed Up", &Event{}); err != nil {
+			t.Fatalf("Track: %v", err)
+		}
+	}
+
+	b.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(batches) != 1 {
+		t.Fatalf("expected exactly 1 batch request from Flush, got %d", len(batches))
+	}
+	if len(batches[0]) != n {
+		t.Fatalf("expected Flush to have sent all %d queued events, got %d", n, len(batches[0]))
+	}
+}
+
+// TestBufferedOnErrorFiresOnFailedFlush is a regression test for
+// 27a34ad: a batch failure surfaced during the background flush loop
+// must still reach OnError, rather than being dropped silently.
+func TestBufferedOnErrorFiresOnFailedFlush(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decodeBatchBody(t, r)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	errCh := make(chan error, 1)
+
+	b := NewBuffered("tok", srv.URL, BufferOptions{
+		BatchSize:     50,
+		FlushInterval: time.Hour,
+		OnError: func(err error) {
+			select {
+			case errCh <- err:
+			default:
+			}
+		},
+	})
+	defer b.Close()
+
+	b.Track("13793", "Signed Up", &Event{})
+	b.Flush()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatalf("expected a non-nil error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnError was never called for a failed flush")
+	}
+}
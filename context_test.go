@@ -0,0 +1,114 @@
+package mixpanel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// blockingHandler returns an http.HandlerFunc that blocks until the
+// request's context is done, simulating a slow or hung server so tests
+// can assert that a client-side cancellation or deadline actually aborts
+// the in-flight request rather than waiting for a response.
+func blockingHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+}
+
+func TestTrackContextCanceledAbortsInFlightRequest(t *testing.T) {
+	srv := httptest.NewServer(blockingHandler())
+	defer srv.Close()
+
+	m := New("tok", srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	err := m.TrackContext(ctx, "13793", "Signed Up", &Event{})
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected an error from a canceled context")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a context.Canceled error, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("TrackContext took %v to return after cancellation, want well under 1s", elapsed)
+	}
+}
+
+func TestTrackContextDeadlineExceededAbortsInFlightRequest(t *testing.T) {
+	srv := httptest.NewServer(blockingHandler())
+	defer srv.Close()
+
+	m := New("tok", srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := m.TrackContext(ctx, "13793", "Signed Up", &Event{})
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected an error from an expired context deadline")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a context.DeadlineExceeded error, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("TrackContext took %v to return after its deadline, want well under 1s", elapsed)
+	}
+}
+
+func TestWithTimeoutBoundsCallWithNoContextDeadline(t *testing.T) {
+	srv := httptest.NewServer(blockingHandler())
+	defer srv.Close()
+
+	m := New("tok", srv.URL, WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	err := m.Track("13793", "Signed Up", &Event{})
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected an error once WithTimeout's deadline elapsed")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a context.DeadlineExceeded error, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("Track took %v to return after WithTimeout elapsed, want well under 1s", elapsed)
+	}
+}
+
+func TestSetDeadlineBoundsCallWithNoContextDeadline(t *testing.T) {
+	srv := httptest.NewServer(blockingHandler())
+	defer srv.Close()
+
+	m := New("tok", srv.URL)
+	m.SetDeadline(time.Now().Add(20 * time.Millisecond))
+
+	start := time.Now()
+	err := m.Track("13793", "Signed Up", &Event{})
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected an error once SetDeadline's deadline elapsed")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a context.DeadlineExceeded error, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("Track took %v to return after SetDeadline elapsed, want well under 1s", elapsed)
+	}
+}
@@ -0,0 +1,38 @@
+package mixpanel
+
+import "context"
+
+// GroupUpdate represents a Mixpanel Group Analytics profile update, sent
+// to /groups. It mirrors Update, but is keyed by a group key/ID pair
+// rather than a distinct ID.
+type GroupUpdate struct {
+	// Operation is the Mixpanel group update operation, e.g. "$set",
+	// "$set_once", "$union", "$unset", "$remove" or "$delete".
+	Operation string
+
+	// Properties holds the operation's payload.
+	Properties map[string]interface{}
+}
+
+func (m *mixpanel) Group(groupKey, groupID string, u *GroupUpdate) error {
+	return m.GroupContext(context.Background(), groupKey, groupID, u)
+}
+
+func (m *mixpanel) GroupContext(ctx context.Context, groupKey, groupID string, u *GroupUpdate) error {
+	return m.send(ctx, "/groups", m.groupParams(groupKey, groupID, u))
+}
+
+// groupParams builds the JSON-able payload for a single /groups update.
+func (m *mixpanel) groupParams(groupKey, groupID string, u *GroupUpdate) map[string]interface{} {
+	props := map[string]interface{}{}
+	for k, v := range u.Properties {
+		props[k] = v
+	}
+
+	return map[string]interface{}{
+		"$token":     m.Token,
+		"$group_key": groupKey,
+		"$group_id":  groupID,
+		u.Operation:  props,
+	}
+}
@@ -0,0 +1,84 @@
+package mixpanel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// sendImport POSTs a single event to Mixpanel's v2 /import endpoint,
+// authenticated with the client's importAuth credentials. Unlike /track
+// and the legacy /import path, the v2 endpoint takes its payload as a
+// plain JSON array in the request body rather than base64-encoded in the
+// query string, and requires Basic auth rather than a token in the
+// payload.
+func (m *mixpanel) sendImport(ctx context.Context, params map[string]interface{}) error {
+	// The v2 endpoint authenticates the whole request via the Basic auth
+	// header set below, and rejects token-only requests, so the token
+	// trackParams adds for the legacy endpoints doesn't belong here.
+	if props, ok := params["properties"].(map[string]interface{}); ok {
+		delete(props, "token")
+	}
+
+	data, err := json.Marshal([]map[string]interface{}{params})
+	if err != nil {
+		return err
+	}
+
+	reqURL := m.ApiURL + "/import"
+	if m.importAuth.projectID != "" {
+		reqURL += "?project_id=" + m.importAuth.projectID
+	}
+
+	ctx, cancel := m.callContext(ctx)
+	defer cancel()
+
+	return m.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(data))
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth(m.importAuth.user, m.importAuth.pass)
+
+		resp, err := m.Client.Do(req)
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+
+		return classifyImportResponse(reqURL, body, resp.StatusCode, resp.Header)
+	})
+}
+
+// classifyImportResponse interprets a v2 /import response. Unlike the
+// legacy endpoints, a rejected event is reported as a non-200 status
+// (commonly 400) with a JSON error body rather than a "0" body.
+func classifyImportResponse(reqURL string, body []byte, statusCode int, header http.Header) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return &MixpanelError{
+			Err: &ErrRateLimited{RetryAfter: parseRetryAfter(header.Get("Retry-After"))},
+			URL: reqURL,
+		}
+	case statusCode >= 500:
+		return &MixpanelError{
+			Err: &ErrServerUnavailable{StatusCode: statusCode},
+			URL: reqURL,
+		}
+	case statusCode != http.StatusOK:
+		return &MixpanelError{
+			Err: &ErrTrackFailed{Body: string(body)},
+			URL: reqURL,
+		}
+	}
+
+	return nil
+}
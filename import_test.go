@@ -0,0 +1,123 @@
+package mixpanel
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestImportWithSecretUsesAuthenticatedV2Endpoint(t *testing.T) {
+	var gotUser, gotPass string
+	var gotPath, gotQuery, gotContentType string
+	var gotBody []map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		gotContentType = r.Header.Get("Content-Type")
+
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("decode request body: %v", err)
+		}
+
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	c := NewWithSecret("e3bc4100330c35722740fb8c6f5abddc", "api-secret", srv.URL)
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	err := c.Import("13793", "Signed Up", &Event{
+		Timestamp: &old,
+		Properties: map[string]interface{}{
+			"Referred By": "Friend",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPath != "/import" {
+		t.Errorf("path = %q, want /import", gotPath)
+	}
+	if gotQuery != "" {
+		t.Errorf("query = %q, want empty (no project_id for a secret-authenticated client)", gotQuery)
+	}
+	if gotUser != "api-secret" || gotPass != "" {
+		t.Errorf("BasicAuth = %q/%q, want api-secret/<empty>", gotUser, gotPass)
+	}
+	if gotContentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", gotContentType)
+	}
+
+	if len(gotBody) != 1 {
+		t.Fatalf("expected a 1-event JSON array body, got %d elements", len(gotBody))
+	}
+	props, ok := gotBody[0]["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a properties object in the body, got %#v", gotBody[0])
+	}
+	if _, hasToken := props["token"]; hasToken {
+		t.Errorf("v2 /import payload should not carry a token property when authenticated, got %v", props["token"])
+	}
+	if props["distinct_id"] != "13793" {
+		t.Errorf("distinct_id = %v, want 13793", props["distinct_id"])
+	}
+}
+
+func TestImportWithServiceAccountAppendsProjectID(t *testing.T) {
+	var gotUser, gotPass, gotQuery string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		gotQuery = r.URL.RawQuery
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	c := NewWithServiceAccount("tok", "987", "svc-account.user", "svc-pass", srv.URL)
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	err := c.Import("13793", "Signed Up", &Event{Timestamp: &old})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotQuery != "project_id=987" {
+		t.Errorf("query = %q, want project_id=987", gotQuery)
+	}
+	if gotUser != "svc-account.user" || gotPass != "svc-pass" {
+		t.Errorf("BasicAuth = %q/%q, want svc-account.user/svc-pass", gotUser, gotPass)
+	}
+}
+
+func TestImportWithSecretFallsBackToLegacyPathForRecentEvents(t *testing.T) {
+	var gotPath string
+	var gotUser string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _, _ = r.BasicAuth()
+		gotPath = r.URL.Path
+		w.WriteHeader(200)
+		w.Write([]byte("1\n"))
+	}))
+	defer srv.Close()
+
+	c := NewWithSecret("tok", "api-secret", srv.URL)
+
+	// No timestamp: not old enough to be routed to the authenticated v2
+	// path, so this should still go through the legacy /track request.
+	if err := c.Import("13793", "Signed Up", &Event{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPath != "/track" {
+		t.Errorf("path = %q, want /track", gotPath)
+	}
+	if gotUser != "" {
+		t.Errorf("expected no Basic auth on the legacy path, got user %q", gotUser)
+	}
+}
@@ -0,0 +1,427 @@
+// Package mixpanel provides a client for sending events and profile updates
+// to the Mixpanel HTTP API (https://mixpanel.com/help/reference/http).
+package mixpanel
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// importCutoff is how far in the past an event's timestamp has to be
+	// before it is routed to the /import endpoint instead of /track.
+	importCutoff = 5 * 24 * time.Hour
+
+	// USApiURL is Mixpanel's default, US-hosted API base URL.
+	USApiURL = "https://api.mixpanel.com"
+
+	// EUApiURL is Mixpanel's EU-hosted API base URL, for projects that
+	// opted into EU data residency.
+	EUApiURL = "https://api-eu.mixpanel.com"
+)
+
+// Mixpanel is the interface for sending events and profile updates to
+// Mixpanel.
+type Mixpanel interface {
+	// Track sends a single event, immediately, to Mixpanel.
+	Track(distinctID, eventName string, e *Event) error
+
+	// TrackContext is like Track but binds the request to ctx, so callers
+	// can cancel it or attach a deadline.
+	TrackContext(ctx context.Context, distinctID, eventName string, e *Event) error
+
+	// Import sends a single event to Mixpanel, routing it to the /import
+	// endpoint instead of /track when its timestamp is older than
+	// Mixpanel's accepted track window.
+	Import(distinctID, eventName string, e *Event) error
+
+	// ImportContext is like Import but binds the request to ctx.
+	ImportContext(ctx context.Context, distinctID, eventName string, e *Event) error
+
+	// Update sends a profile update to Mixpanel's /engage endpoint.
+	Update(distinctID string, u *Update) error
+
+	// UpdateContext is like Update but binds the request to ctx.
+	UpdateContext(ctx context.Context, distinctID string, u *Update) error
+
+	// TrackBatch sends up to len(events) events to Mixpanel's /track
+	// endpoint, automatically split into batches of up to 50 events as
+	// required by the API.
+	TrackBatch(events []BatchEvent) error
+
+	// UpdateBatch sends up to len(updates) profile updates to Mixpanel's
+	// /engage endpoint, automatically split into batches of up to 50
+	// updates as required by the API.
+	UpdateBatch(updates []BatchUpdate) error
+
+	// Group sends a group profile update to Mixpanel's /groups endpoint,
+	// for the group identified by groupKey (e.g. "company_id") and
+	// groupID.
+	Group(groupKey, groupID string, u *GroupUpdate) error
+
+	// GroupContext is like Group but binds the request to ctx.
+	GroupContext(ctx context.Context, groupKey, groupID string, u *GroupUpdate) error
+
+	// SetDeadline sets the absolute deadline applied to any call that
+	// isn't made through a *Context method with its own context deadline.
+	// It is safe to call concurrently with in-flight requests. The
+	// deadline is read when a call starts: calls already in flight keep
+	// the deadline they started with and are not affected.
+	SetDeadline(deadline time.Time)
+}
+
+// Event is a single Mixpanel event.
+type Event struct {
+	// IP is the IP address to use for geolocating the event. Leave it
+	// empty to let Mixpanel use the request's IP, or set it to "0" to
+	// disable geolocation entirely.
+	IP string
+
+	// Timestamp is the time the event occurred. If nil, Mixpanel will use
+	// the time it received the event.
+	Timestamp *time.Time
+
+	// Properties holds the event's custom properties.
+	Properties map[string]interface{}
+
+	// Groups maps a group key (e.g. "company_id") to the identifier of
+	// the group this event should be associated with, for use with Group
+	// Analytics.
+	Groups map[string]string
+}
+
+// Update represents a Mixpanel People profile update, sent to /engage.
+type Update struct {
+	// IP is the IP address to use for geolocating the profile.
+	IP string
+
+	// Timestamp is the time the update occurred.
+	Timestamp *time.Time
+
+	// Operation is the Mixpanel profile update operation, e.g. "$set",
+	// "$set_once", "$add", "$union", "$unset" or "$delete".
+	Operation string
+
+	// Properties holds the operation's payload.
+	Properties map[string]interface{}
+}
+
+// MixpanelError wraps an error returned while talking to the Mixpanel API,
+// recording the URL that was requested when the error occurred.
+type MixpanelError struct {
+	Err error
+	URL string
+}
+
+func (err *MixpanelError) Error() string {
+	return "mixpanel: " + err.Err.Error() + " - " + err.URL
+}
+
+func (err *MixpanelError) Unwrap() error {
+	return err.Err
+}
+
+// ErrTrackFailed is returned when Mixpanel responds with "0", indicating
+// that it rejected the event or update. For the v2 /import endpoint it is
+// also returned for any non-200 status other than 429 or 5xx.
+type ErrTrackFailed struct {
+	Body string
+}
+
+func (err *ErrTrackFailed) Error() string {
+	return "mixpanel did not accept request: " + err.Body
+}
+
+type mixpanel struct {
+	Client *http.Client
+	Token  string
+	ApiURL string
+
+	// importAuth holds the credentials used to authenticate requests to
+	// the v2 /import endpoint, if any were configured. Events older than
+	// importCutoff are only routed through the authenticated v2 path when
+	// importAuth is set; otherwise they fall back to the legacy,
+	// token-only /import request.
+	importAuth *importCredentials
+
+	// timeout is the default per-call timeout applied via WithTimeout,
+	// used by calls that don't already carry a context deadline.
+	timeout time.Duration
+
+	deadlineMu sync.RWMutex
+	// deadline is the absolute deadline set via SetDeadline, if any. It
+	// takes precedence over timeout.
+	deadline time.Time
+
+	// retry is the policy used to retry transient failures. Its zero
+	// value disables retries (a single attempt).
+	retry RetryPolicy
+}
+
+// Option configures optional client behavior, passed to New,
+// NewWithSecret or NewWithServiceAccount.
+type Option func(*mixpanel)
+
+// WithHTTPClient configures the *http.Client used to make requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(m *mixpanel) { m.Client = c }
+}
+
+// WithTimeout sets a default per-call timeout, applied to any call that
+// isn't made through a *Context method with its own context deadline.
+func WithTimeout(d time.Duration) Option {
+	return func(m *mixpanel) { m.timeout = d }
+}
+
+// importCredentials are the Basic-auth credentials Mixpanel's v2 /import
+// endpoint requires, either a project API secret or a service account.
+type importCredentials struct {
+	user      string
+	pass      string
+	projectID string
+}
+
+// New returns a Mixpanel client that sends events and updates using the
+// given project token to the given API base URL (e.g. USApiURL or
+// EUApiURL).
+func New(token, apiURL string, opts ...Option) Mixpanel {
+	return newMixpanel(http.DefaultClient, token, apiURL, opts...)
+}
+
+// NewWithSecret is like New but also configures the project's API secret,
+// which is used to authenticate requests to Mixpanel's v2 /import
+// endpoint for events older than the import cutoff.
+func NewWithSecret(token, secret, apiURL string, opts ...Option) Mixpanel {
+	m := newMixpanel(http.DefaultClient, token, apiURL, opts...)
+	m.importAuth = &importCredentials{user: secret}
+	return m
+}
+
+// NewWithServiceAccount is like New but also configures a Mixpanel
+// service account, used to authenticate requests to Mixpanel's v2
+// /import endpoint for events older than the import cutoff.
+func NewWithServiceAccount(token, projectID, user, pass, apiURL string, opts ...Option) Mixpanel {
+	m := newMixpanel(http.DefaultClient, token, apiURL, opts...)
+	m.importAuth = &importCredentials{user: user, pass: pass, projectID: projectID}
+	return m
+}
+
+// NewFromClient is like New but allows the caller to supply their own
+// *http.Client, for example to configure timeouts or transport-level
+// settings. Equivalent to New(token, apiURL, WithHTTPClient(c)).
+func NewFromClient(c *http.Client, token, apiURL string) Mixpanel {
+	return newMixpanel(c, token, apiURL)
+}
+
+func newMixpanel(c *http.Client, token, apiURL string, opts ...Option) *mixpanel {
+	if apiURL == "" {
+		apiURL = USApiURL
+	}
+
+	m := &mixpanel{
+		Client: c,
+		Token:  token,
+		ApiURL: apiURL,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// SetDeadline sets the absolute deadline applied to calls that don't
+// already carry a context deadline. It is safe to call from any
+// goroutine, including while other calls are in flight.
+func (m *mixpanel) SetDeadline(deadline time.Time) {
+	m.deadlineMu.Lock()
+	m.deadline = deadline
+	m.deadlineMu.Unlock()
+}
+
+// callContext derives the context.Context to use for a request: ctx as
+// given if it already has a deadline, otherwise ctx bounded by
+// SetDeadline or WithTimeout, whichever was configured.
+func (m *mixpanel) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	m.deadlineMu.RLock()
+	deadline := m.deadline
+	m.deadlineMu.RUnlock()
+
+	if !deadline.IsZero() {
+		return context.WithDeadline(ctx, deadline)
+	}
+
+	if m.timeout > 0 {
+		return context.WithTimeout(ctx, m.timeout)
+	}
+
+	return ctx, func() {}
+}
+
+func (m *mixpanel) Track(distinctID, eventName string, e *Event) error {
+	return m.TrackContext(context.Background(), distinctID, eventName, e)
+}
+
+func (m *mixpanel) TrackContext(ctx context.Context, distinctID, eventName string, e *Event) error {
+	return m.track(ctx, eventName, distinctID, e, false)
+}
+
+func (m *mixpanel) Import(distinctID, eventName string, e *Event) error {
+	return m.ImportContext(context.Background(), distinctID, eventName, e)
+}
+
+func (m *mixpanel) ImportContext(ctx context.Context, distinctID, eventName string, e *Event) error {
+	useImport := e.Timestamp != nil && time.Since(*e.Timestamp) > importCutoff
+
+	if useImport && m.importAuth != nil {
+		return m.sendImport(ctx, m.trackParams(eventName, distinctID, e))
+	}
+
+	return m.track(ctx, eventName, distinctID, e, useImport)
+}
+
+func (m *mixpanel) track(ctx context.Context, eventName, distinctID string, e *Event, useImport bool) error {
+	endpoint := "/track"
+	if useImport {
+		endpoint = "/import"
+	}
+
+	return m.send(ctx, endpoint, m.trackParams(eventName, distinctID, e))
+}
+
+// trackParams builds the JSON-able payload for a single /track or /import
+// event, shared by the single-event and batch send paths.
+func (m *mixpanel) trackParams(eventName, distinctID string, e *Event) map[string]interface{} {
+	props := map[string]interface{}{}
+	for k, v := range e.Properties {
+		props[k] = v
+	}
+
+	props["token"] = m.Token
+	props["distinct_id"] = distinctID
+
+	for groupKey, groupID := range e.Groups {
+		props[groupKey] = groupID
+	}
+
+	if e.IP != "" {
+		props["ip"] = e.IP
+	}
+
+	if e.Timestamp != nil {
+		props["time"] = e.Timestamp.Unix()
+	}
+
+	return map[string]interface{}{
+		"event":      eventName,
+		"properties": props,
+	}
+}
+
+func (m *mixpanel) Update(distinctID string, u *Update) error {
+	return m.UpdateContext(context.Background(), distinctID, u)
+}
+
+func (m *mixpanel) UpdateContext(ctx context.Context, distinctID string, u *Update) error {
+	return m.send(ctx, "/engage", m.updateParams(distinctID, u))
+}
+
+// updateParams builds the JSON-able payload for a single /engage profile
+// update, shared by the single-update and batch send paths.
+func (m *mixpanel) updateParams(distinctID string, u *Update) map[string]interface{} {
+	props := map[string]interface{}{}
+	for k, v := range u.Properties {
+		props[k] = v
+	}
+
+	params := map[string]interface{}{
+		"$token":       m.Token,
+		"$distinct_id": distinctID,
+		u.Operation:    props,
+	}
+
+	if u.IP != "" {
+		params["$ip"] = u.IP
+	}
+
+	if u.Timestamp != nil {
+		params["$time"] = u.Timestamp.Unix()
+	}
+
+	return params
+}
+
+func (m *mixpanel) send(ctx context.Context, endpoint string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	reqURL := m.ApiURL + endpoint + "?data=" + base64.StdEncoding.EncodeToString(data)
+
+	ctx, cancel := m.callContext(ctx)
+	defer cancel()
+
+	return m.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+
+		resp, err := m.Client.Do(req)
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return &MixpanelError{Err: err, URL: reqURL}
+		}
+
+		return checkResponse(reqURL, body, resp.StatusCode, resp.Header)
+	})
+}
+
+// checkResponse interprets a Mixpanel API response, returning a
+// *MixpanelError classifying how it failed, if it did.
+func checkResponse(reqURL string, body []byte, statusCode int, header http.Header) error {
+	if bytes.Equal(bytes.TrimSpace(body), []byte("0")) {
+		return &MixpanelError{
+			Err: &ErrTrackFailed{Body: string(body)},
+			URL: reqURL,
+		}
+	}
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return &MixpanelError{
+			Err: &ErrRateLimited{RetryAfter: parseRetryAfter(header.Get("Retry-After"))},
+			URL: reqURL,
+		}
+	case statusCode >= 500:
+		return &MixpanelError{
+			Err: &ErrServerUnavailable{StatusCode: statusCode},
+			URL: reqURL,
+		}
+	case statusCode != http.StatusOK:
+		return &MixpanelError{
+			Err: fmt.Errorf("unexpected status code %d", statusCode),
+			URL: reqURL,
+		}
+	}
+
+	return nil
+}
@@ -152,6 +152,34 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestGroup(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.Group("company_id", "1234", &GroupUpdate{
+		Operation: "$set",
+		Properties: map[string]interface{}{
+			"Name":     "Acme Inc.",
+			"Industry": "Widgets",
+		},
+	})
+
+	want := "{\"$group_id\":\"1234\",\"$group_key\":\"company_id\",\"$set\":{\"Industry\":\"Widgets\",\"Name\":\"Acme Inc.\"},\"$token\":\"e3bc4100330c35722740fb8c6f5abddc\"}"
+
+	if !reflect.DeepEqual(decodeURL(LastRequest.URL.String()), want) {
+		t.Errorf("LastRequest.URL returned %+v, want %+v",
+			decodeURL(LastRequest.URL.String()), want)
+	}
+
+	want = "/groups"
+	path := LastRequest.URL.Path
+
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("path returned %+v, want %+v",
+			path, want)
+	}
+}
+
 func TestError(t *testing.T) {
 	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -0,0 +1,163 @@
+package mixpanel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how the client retries transient failures such
+// as 5xx responses, rate limiting, and timed-out network requests.
+// Validation failures (ErrTrackFailed) are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// <= 1 disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the backoff before the first retry. It doubles
+	// with each subsequent attempt, up to MaxBackoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the backoff between retries.
+	MaxBackoff time.Duration
+
+	// Jitter, if true, applies full jitter to the computed backoff
+	// (a random duration between 0 and the computed backoff).
+	Jitter bool
+
+	// ShouldRetry overrides the default classification of which errors
+	// are retryable (see IsRetryable). Leave nil to use the default.
+	ShouldRetry func(error) bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 200 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 10 * time.Second
+	}
+	if p.ShouldRetry == nil {
+		p.ShouldRetry = IsRetryable
+	}
+	return p
+}
+
+// WithRetry configures the client's retry policy.
+func WithRetry(p RetryPolicy) Option {
+	return func(m *mixpanel) { m.retry = p.withDefaults() }
+}
+
+// ErrServerUnavailable indicates Mixpanel responded with a 5xx status.
+type ErrServerUnavailable struct {
+	StatusCode int
+}
+
+func (err *ErrServerUnavailable) Error() string {
+	return fmt.Sprintf("mixpanel server unavailable: status %d", err.StatusCode)
+}
+
+// ErrRateLimited indicates Mixpanel responded with 429, optionally
+// carrying the Retry-After duration it asked for.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (err *ErrRateLimited) Error() string {
+	return "mixpanel rate limited the request"
+}
+
+// IsRetryable reports whether err represents a transient failure worth
+// retrying: Mixpanel reporting 5xx or 429, or a timed-out network error.
+// It never considers an *ErrTrackFailed (Mixpanel rejected the event
+// outright) retryable, since resending the same payload will only fail
+// again.
+func IsRetryable(err error) bool {
+	var merr *MixpanelError
+	if errors.As(err, &merr) {
+		err = merr.Err
+	}
+
+	var rateLimited *ErrRateLimited
+	if errors.As(err, &rateLimited) {
+		return true
+	}
+
+	var unavailable *ErrServerUnavailable
+	if errors.As(err, &unavailable) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+
+	return false
+}
+
+// backoff computes how long to wait before the next attempt, honoring a
+// rate limit's Retry-After when present, and otherwise using capped
+// exponential backoff with optional full jitter.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var merr *MixpanelError
+	var rateLimited *ErrRateLimited
+	if errors.As(err, &merr) && errors.As(merr.Err, &rateLimited) && rateLimited.RetryAfter > 0 {
+		return rateLimited.RetryAfter
+	}
+
+	wait := p.InitialBackoff << uint(attempt)
+	if wait <= 0 || wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+
+	if p.Jitter {
+		wait = time.Duration(rand.Int63n(int64(wait) + 1))
+	}
+
+	return wait
+}
+
+// parseRetryAfter parses a Retry-After header value given in seconds. It
+// returns 0 if the header is absent or not a plain integer (e.g. an
+// HTTP-date, which Mixpanel does not send).
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// do runs fn, retrying per m.retry while the error is retryable and ctx
+// hasn't been canceled.
+func (m *mixpanel) do(ctx context.Context, fn func() error) error {
+	policy := m.retry.withDefaults()
+
+	var err error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		if attempt == policy.MaxAttempts-1 || !policy.ShouldRetry(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(policy.backoff(attempt, err)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+
+	return err
+}
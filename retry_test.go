@@ -0,0 +1,103 @@
+package mixpanel
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRetrySucceedsAfterOneRetryOn500(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte("1\n"))
+	}))
+	defer srv.Close()
+
+	m := New("tok", srv.URL, WithRetry(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}))
+
+	if err := m.Track("13793", "Signed Up", &Event{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 requests (1 failure + 1 retry), got %d", calls)
+	}
+}
+
+func TestWithRetryHonorsRetryAfterOn429(t *testing.T) {
+	var calls int32
+	var firstCallAt, secondCallAt time.Time
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			firstCallAt = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		secondCallAt = time.Now()
+		w.WriteHeader(200)
+		w.Write([]byte("1\n"))
+	}))
+	defer srv.Close()
+
+	m := New("tok", srv.URL, WithRetry(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}))
+
+	if err := m.Track("13793", "Signed Up", &Event{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 requests (1 rate-limited + 1 retry), got %d", calls)
+	}
+
+	if wait := secondCallAt.Sub(firstCallAt); wait < time.Second {
+		t.Fatalf("retry fired after %v, want it to honor the 1s Retry-After", wait)
+	}
+}
+
+func TestWithRetryDoesNotRetryErrTrackFailed(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(200)
+		w.Write([]byte("0\n"))
+	}))
+	defer srv.Close()
+
+	m := New("tok", srv.URL, WithRetry(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}))
+
+	err := m.Track("13793", "Signed Up", &Event{})
+	if err == nil {
+		t.Fatal("expected an error for a rejected event")
+	}
+	if calls != 1 {
+		t.Fatalf("expected a rejected event (*ErrTrackFailed) not to be retried, got %d requests", calls)
+	}
+
+	var merr *MixpanelError
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected a *MixpanelError, got %T: %v", err, err)
+	}
+	var trackFailed *ErrTrackFailed
+	if !errors.As(merr.Err, &trackFailed) {
+		t.Fatalf("expected the underlying error to be *ErrTrackFailed, got %T: %v", merr.Err, merr.Err)
+	}
+}